@@ -0,0 +1,163 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// PatchBuilder accumulates JSON-Patch operations for obj and emits them as a single request once Build is called.
+// Use it when a reconciler needs to apply several otherwise-independent changes (e.g. adding a finalizer and
+// replacing the spec) atomically, rather than issuing one clt.Patch call per change
+type PatchBuilder struct {
+	ctx         context.Context
+	clt         client.Client
+	obj         client.Object
+	ops         []string
+	finalizers  []string
+	subresource string
+	err         error
+}
+
+// NewPatchBuilder creates a PatchBuilder targeting obj. Chain Add/Remove/Upsert/Replace/Test calls to accumulate
+// operations, then call Build to get the combined JsonPatch and PatchFunc
+func NewPatchBuilder(ctx context.Context, clt client.Client, obj client.Object) *PatchBuilder {
+	return &PatchBuilder{ctx: ctx, clt: clt, obj: obj}
+}
+
+// WithSubresource directs Build's PatchFunc at the named subresource (e.g. "status" or "scale") instead of the main
+// resource
+func (b *PatchBuilder) WithSubresource(subresource string) *PatchBuilder {
+	b.subresource = subresource
+	return b
+}
+
+// pendingFinalizers returns b's working copy of obj's finalizers, seeded from obj on first use and then kept in
+// sync as AddFinalizer/RemoveFinalizer queue ops, so that a batch containing more than one finalizer op computes
+// each op's path/index against what the list will actually look like by the time it is applied
+func (b *PatchBuilder) pendingFinalizers() []string {
+	if b.finalizers == nil {
+		b.finalizers = append([]string{}, b.obj.GetFinalizers()...)
+	}
+	return b.finalizers
+}
+
+// AddFinalizer queues an operation adding finalizer to obj, following the same by-index convention as
+// JsonPatchFinalizerIn
+func (b *PatchBuilder) AddFinalizer(finalizer string) *PatchBuilder {
+	marshaled, err := json.Marshal(finalizer)
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	if len(b.pendingFinalizers()) == 0 {
+		b.ops = append(b.ops, fmt.Sprintf("{\"op\": \"add\", \"path\": \"/metadata/finalizers\", \"value\": [%s]}", marshaled))
+	} else {
+		b.ops = append(b.ops, fmt.Sprintf("{\"op\": \"add\", \"path\": \"/metadata/finalizers/-\", \"value\": %s}", marshaled))
+	}
+	b.finalizers = append(b.finalizers, finalizer)
+	return b
+}
+
+// RemoveFinalizer queues an operation removing finalizer from obj, following the same by-index convention as
+// JsonPatchFinalizerOut. It is a no-op if the finalizer isn't present on obj
+func (b *PatchBuilder) RemoveFinalizer(finalizer string) *PatchBuilder {
+	finalizers := b.pendingFinalizers()
+
+	idx := -1
+	for i, fin := range finalizers {
+		if fin == finalizer {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return b
+	}
+
+	if len(finalizers) == 1 {
+		b.ops = append(b.ops, "{\"op\": \"remove\", \"path\": \"/metadata/finalizers\"}")
+	} else {
+		b.ops = append(b.ops, fmt.Sprintf("{\"op\": \"remove\", \"path\": \"/metadata/finalizers/%d\"}", idx))
+	}
+	b.finalizers = append(finalizers[:idx], finalizers[idx+1:]...)
+	return b
+}
+
+// UpsertMap queues operations to add or replace all members in the given path with the members in newMap; we use
+// origMap to determine whether we need to add or replace, following the same convention as JsonPatchMap
+func (b *PatchBuilder) UpsertMap(path string, origMap, newMap map[string]string) *PatchBuilder {
+	b.ops = append(b.ops, jsonPatchMapOps(path, origMap, newMap)...)
+	return b
+}
+
+// ReplaceSpec queues an operation replacing obj's /spec with spec, following the same convention as JsonPatchSpec.
+// If marshaling spec fails, the error is surfaced from Build
+func (b *PatchBuilder) ReplaceSpec(spec any) *PatchBuilder {
+	return b.replace("/spec", spec)
+}
+
+// ReplaceStatus queues an operation replacing obj's /status with status. If marshaling status fails, the error is
+// surfaced from Build
+func (b *PatchBuilder) ReplaceStatus(status any) *PatchBuilder {
+	return b.replace("/status", status)
+}
+
+func (b *PatchBuilder) replace(path string, value any) *PatchBuilder {
+	marshaled, err := json.Marshal(value)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.ops = append(b.ops, fmt.Sprintf("{\"op\": \"replace\", \"path\": \"%s\", \"value\": %s}", path, marshaled))
+	return b
+}
+
+// Test queues an RFC 6902 "test" operation asserting that path currently holds value. This gives callers a cheap
+// optimistic-concurrency check - e.g. testing /metadata/resourceVersion - as an alternative to resourceVersion-gated
+// updates; if the test fails, the whole patch is rejected by the API server
+func (b *PatchBuilder) Test(path, value string) *PatchBuilder {
+	marshaled, err := json.Marshal(value)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.ops = append(b.ops, fmt.Sprintf("{\"op\": \"test\", \"path\": \"%s\", \"value\": %s}", path, marshaled))
+	return b
+}
+
+// Build assembles the queued operations into a single JSON-Patch array. It returns the JsonPatch for you to log, the
+// PatchFunc for you to execute, and an error if any of the queued operations failed to marshal or if nothing was
+// queued
+func (b *PatchBuilder) Build() (JsonPatch, PatchFunc, error) {
+	if b.err != nil {
+		return JsonPatch{""}, nil, b.err
+	}
+	if len(b.ops) == 0 {
+		return JsonPatch{""}, nil, &NoPatchRequired{"nothing to patch"}
+	}
+
+	patch := JsonPatch{"[" + strings.Join(b.ops, ",") + "]"}
+	return patch, func(opts ...client.PatchOption) error {
+		rawPatch := client.RawPatch(types.JSONPatchType, []byte(patch.Get()))
+		if b.subresource == "" {
+			return b.clt.Patch(b.ctx, b.obj, rawPatch, opts...)
+		}
+
+		subOpts := make([]client.SubResourcePatchOption, len(opts))
+		for i, opt := range opts {
+			subOpt, ok := opt.(client.SubResourcePatchOption)
+			if !ok {
+				return fmt.Errorf("patch option %T does not support subresource patches", opt)
+			}
+			subOpts[i] = subOpt
+		}
+		return b.clt.SubResource(b.subresource).Patch(b.ctx, b.obj, rawPatch, subOpts...)
+	}, nil
+}
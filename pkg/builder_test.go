@@ -0,0 +1,154 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// onlyPatchOption implements client.PatchOption but not client.SubResourcePatchOption, letting tests exercise a
+// patch option that doesn't apply to subresource patches
+type onlyPatchOption struct{}
+
+func (onlyPatchOption) ApplyToPatch(*client.PatchOptions) {}
+
+var _ = Context("PatchBuilder", func() {
+	It("should combine a finalizer and a map upsert into a single patch", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-builder-obj-1",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		_, patchFn, err := NewPatchBuilder(ctx, clt, obj).
+			AddFinalizer("add-my/finalizer").
+			UpsertMap("/metadata/annotations", obj.Annotations, map[string]string{"annotation_key1": "annotation_value1"}).
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patchFn()).To(Succeed())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(ContainElement("add-my/finalizer"))
+		Expect(obj.Annotations["annotation_key1"]).To(Equal("annotation_value1"))
+	})
+
+	It("should add multiple finalizers queued in the same build", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-builder-obj-4",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		_, patchFn, err := NewPatchBuilder(ctx, clt, obj).
+			AddFinalizer("first/finalizer").
+			AddFinalizer("second/finalizer").
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patchFn()).To(Succeed())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(ContainElement("first/finalizer"))
+		Expect(obj.Finalizers).To(ContainElement("second/finalizer"))
+	})
+
+	It("should remove multiple finalizers queued in the same build", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "dummy-builder-obj-5",
+				Finalizers: []string{"first/finalizer", "second/finalizer", "third/finalizer"},
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		_, patchFn, err := NewPatchBuilder(ctx, clt, obj).
+			RemoveFinalizer("first/finalizer").
+			RemoveFinalizer("second/finalizer").
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patchFn()).To(Succeed())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(Equal([]string{"third/finalizer"}))
+	})
+
+	It("should return NoPatchRequired when nothing was queued", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-builder-obj-2",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		_, _, err := NewPatchBuilder(ctx, clt, obj).Build()
+		Expect(err).To(MatchError("nothing to patch"))
+	})
+
+	It("should fail the test op when the tested value doesn't match", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-builder-obj-3",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		_, patchFn, err := NewPatchBuilder(ctx, clt, obj).
+			Test("/metadata/resourceVersion", "not-the-real-resource-version").
+			AddFinalizer("add-my/finalizer").
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patchFn()).To(HaveOccurred())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(BeEmpty())
+	})
+
+	It("should error instead of panicking when a patch option doesn't apply to subresource patches", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-builder-obj-6",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		_, patchFn, err := NewPatchBuilder(ctx, clt, obj).
+			WithSubresource("status").
+			ReplaceStatus(corev1.NamespaceStatus{}).
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(patchFn(onlyPatchOption{})).To(MatchError(ContainSubstring("does not support subresource patches")))
+	})
+
+	It("should route a non-status subresource through SubResource rather than silently patching the main resource", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-builder-obj-7",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		_, patchFn, err := NewPatchBuilder(ctx, clt, obj).
+			WithSubresource("scale").
+			AddFinalizer("add-my/finalizer").
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patchFn()).To(HaveOccurred())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(BeEmpty())
+	})
+})
@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WithServerDryRun wraps f so that, when invoked, the API server validates the patch via a server-side dry run
+// (client.DryRunAll) instead of persisting it. This is useful for operator authors who want to confirm a patch is
+// accepted (e.g. passes admission webhooks) without committing to it
+func (f PatchFunc) WithServerDryRun() PatchFunc {
+	return func(opts ...client.PatchOption) error {
+		return f(append(opts, client.DryRunAll)...)
+	}
+}
+
+// PreviewPatch applies patchBytes to a JSON-marshaled copy of obj entirely in memory - without sending anything to
+// the API server - and returns the object obj would become plus a human-readable diff between its current and
+// previewed state. patchBytes must already be in the wire format appropriate for patchType, i.e. exactly what you
+// would otherwise hand to client.RawPatch: a JSON-Patch array for types.JSONPatchType, a merge document for
+// types.MergePatchType, or a strategic-merge document for types.StrategicMergePatchType. This is the offline
+// counterpart to a PatchFunc, handy for logging "here is exactly what I'm about to change" or for unit tests that
+// want to assert patch semantics without spinning up envtest
+func PreviewPatch(obj client.Object, patchType types.PatchType, patchBytes []byte) (client.Object, string, error) {
+	origJson, err := json.Marshal(obj)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var modifiedJson []byte
+	switch patchType {
+	case types.JSONPatchType:
+		decoded, err := jsonpatch.DecodePatch(patchBytes)
+		if err != nil {
+			return nil, "", err
+		}
+		modifiedJson, err = decoded.Apply(origJson)
+		if err != nil {
+			return nil, "", err
+		}
+	case types.MergePatchType:
+		modifiedJson, err = jsonpatch.MergePatch(origJson, patchBytes)
+		if err != nil {
+			return nil, "", err
+		}
+	case types.StrategicMergePatchType:
+		modifiedJson, err = strategicpatch.StrategicMergePatch(origJson, patchBytes, obj)
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", fmt.Errorf("unsupported patch type for preview: %s", patchType)
+	}
+
+	previewObj, ok := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+	if !ok {
+		return nil, "", fmt.Errorf("%T does not implement client.Object", obj)
+	}
+	if err := json.Unmarshal(modifiedJson, previewObj); err != nil {
+		return nil, "", err
+	}
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMain(string(origJson), string(modifiedJson), false)
+	return previewObj, dmp.DiffPrettyText(diffs), nil
+}
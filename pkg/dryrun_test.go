@@ -0,0 +1,53 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Context("Dry-run preview", func() {
+	It("should preview the result of a JSON patch without persisting it", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-dryrun-obj-1",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		patch, _, err := JsonPatchFinalizerIn(ctx, clt, obj, "add-my/finalizer")
+		Expect(err).NotTo(HaveOccurred())
+
+		previewed, diff, err := PreviewPatch(obj, types.JSONPatchType, []byte("["+patch.Get()+"]"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diff).NotTo(BeEmpty())
+
+		previewedNs, ok := previewed.(*corev1.Namespace)
+		Expect(ok).To(BeTrue())
+		Expect(previewedNs.Finalizers).To(ContainElement("add-my/finalizer"))
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(BeEmpty())
+	})
+
+	It("should validate a patch against the API server without persisting it via WithServerDryRun", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-dryrun-obj-2",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		_, patchFn := JsonPatchFinalizerInP(ctx, clt, obj, "add-my/finalizer")
+		Expect(patchFn.WithServerDryRun()()).To(Succeed())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(BeEmpty())
+	})
+})
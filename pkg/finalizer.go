@@ -0,0 +1,122 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type (
+	// Result reports which parts of obj a Finalizer (or Finalize) ended up changing
+	Result struct {
+		// Updated is true when the spec (or any non-status part of obj) was patched
+		Updated bool
+		// StatusUpdated is true when obj's status was patched
+		StatusUpdated bool
+	}
+
+	// Finalizer is the cleanup logic registered under a single finalizer key. Finalize is only invoked once obj is
+	// marked for deletion and the key is present in obj's finalizer list
+	Finalizer interface {
+		Finalize(ctx context.Context, obj client.Object) (Result, error)
+	}
+
+	// Finalizers is a registry of Finalizer implementations keyed by finalizer string. Use NewFinalizers to create
+	// one, Register to add entries, and Finalize to run them against an object
+	Finalizers interface {
+		// Register adds fn under key. It returns an error if key is already registered
+		Register(key string, fn Finalizer) error
+		// Finalize adds any missing registered finalizer keys to obj while its DeletionTimestamp is zero, or - once
+		// obj is marked for deletion - runs the Finalizer registered for each key obj still carries and removes that
+		// key once its cleanup succeeds. All finalizer additions/removals are batched into a single JSON-Patch array
+		Finalize(ctx context.Context, clt client.Client, obj client.Object) (Result, error)
+	}
+
+	finalizers struct {
+		registry map[string]Finalizer
+		order    []string
+	}
+)
+
+// NewFinalizers creates an empty Finalizers registry
+func NewFinalizers() Finalizers {
+	return &finalizers{registry: map[string]Finalizer{}}
+}
+
+// Register adds fn under key. It returns an error if key is already registered
+func (f *finalizers) Register(key string, fn Finalizer) error {
+	if _, found := f.registry[key]; found {
+		return fmt.Errorf("finalizer %q is already registered", key)
+	}
+
+	f.registry[key] = fn
+	f.order = append(f.order, key)
+	return nil
+}
+
+// Finalize adds any missing registered finalizer keys to obj while its DeletionTimestamp is zero, or - once obj is
+// marked for deletion - runs the Finalizer registered for each key obj still carries and removes that key once its
+// cleanup succeeds. All finalizer additions/removals are batched into a single JSON-Patch array
+func (f *finalizers) Finalize(ctx context.Context, clt client.Client, obj client.Object) (Result, error) {
+	result := Result{}
+	builder := NewPatchBuilder(ctx, clt, obj)
+	queued := false
+	var finErr error
+
+	if obj.GetDeletionTimestamp().IsZero() {
+		for _, key := range f.order {
+			if hasFinalizer(obj, key) {
+				continue
+			}
+			builder.AddFinalizer(key)
+			queued = true
+		}
+	} else {
+		for _, key := range f.order {
+			if !hasFinalizer(obj, key) {
+				continue
+			}
+
+			finResult, err := f.registry[key].Finalize(ctx, obj)
+			if err != nil {
+				// keep the key, but still run the remaining finalizers and remove the keys whose cleanup succeeded;
+				// the first cleanup error is returned once the patch has been sent
+				if finErr == nil {
+					finErr = err
+				}
+				continue
+			}
+			result.Updated = result.Updated || finResult.Updated
+			result.StatusUpdated = result.StatusUpdated || finResult.StatusUpdated
+
+			builder.RemoveFinalizer(key)
+			queued = true
+		}
+	}
+
+	if !queued {
+		return result, finErr
+	}
+
+	_, patchFn, err := builder.Build()
+	if err != nil {
+		return result, err
+	}
+	if err := patchFn(); err != nil {
+		return result, err
+	}
+
+	result.Updated = true
+	return result, finErr
+}
+
+func hasFinalizer(obj client.Object, key string) bool {
+	for _, fin := range obj.GetFinalizers() {
+		if fin == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	"context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+type countingFinalizer struct {
+	calls  int
+	result Result
+	err    error
+}
+
+func (c *countingFinalizer) Finalize(_ context.Context, _ client.Object) (Result, error) {
+	c.calls++
+	return c.result, c.err
+}
+
+var _ = Context("Finalizers registry", func() {
+	It("should return an error when the same key is registered twice", func() {
+		f := NewFinalizers()
+		Expect(f.Register("my/finalizer", &countingFinalizer{})).To(Succeed())
+		Expect(f.Register("my/finalizer", &countingFinalizer{})).To(MatchError(`finalizer "my/finalizer" is already registered`))
+	})
+
+	It("should add all registered finalizers in a single patch while the object isn't being deleted", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-finalizers-obj-1",
+			},
+		}
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		f := NewFinalizers()
+		Expect(f.Register("first/finalizer", &countingFinalizer{})).To(Succeed())
+		Expect(f.Register("second/finalizer", &countingFinalizer{})).To(Succeed())
+
+		result, err := f.Finalize(ctx, clt, obj)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Updated).To(BeTrue())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(ContainElement("first/finalizer"))
+		Expect(obj.Finalizers).To(ContainElement("second/finalizer"))
+		// both must survive the same Build() - a regression here means the batched finalizer ops are clobbering
+		// each other again
+		Expect(obj.Finalizers).To(HaveLen(2))
+	})
+
+	It("should no-op when every registered finalizer is already present", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "dummy-finalizers-obj-2",
+				Finalizers: []string{"first/finalizer"},
+			},
+		}
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		f := NewFinalizers()
+		Expect(f.Register("first/finalizer", &countingFinalizer{})).To(Succeed())
+
+		result, err := f.Finalize(ctx, clt, obj)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Updated).To(BeFalse())
+	})
+
+	It("should run cleanup and remove the finalizer once the object is marked for deletion", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "dummy-finalizers-obj-3",
+				Finalizers: []string{"first/finalizer"},
+			},
+		}
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+		Expect(clt.Delete(ctx, obj)).To(Succeed())
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.DeletionTimestamp).NotTo(BeNil())
+
+		fin := &countingFinalizer{result: Result{StatusUpdated: true}}
+		f := NewFinalizers()
+		Expect(f.Register("first/finalizer", fin)).To(Succeed())
+
+		result, err := f.Finalize(ctx, clt, obj)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fin.calls).To(Equal(1))
+		Expect(result.Updated).To(BeTrue())
+		Expect(result.StatusUpdated).To(BeTrue())
+	})
+
+	It("should no-op when the object is marked for deletion but the finalizer is absent", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "dummy-finalizers-obj-4",
+				Finalizers:        []string{"unrelated/finalizer"},
+				DeletionTimestamp: &metav1.Time{Time: time.Unix(0, 0)},
+			},
+		}
+
+		fin := &countingFinalizer{}
+		f := NewFinalizers()
+		Expect(f.Register("first/finalizer", fin)).To(Succeed())
+
+		result, err := f.Finalize(ctx, clt, obj)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fin.calls).To(Equal(0))
+		Expect(result.Updated).To(BeFalse())
+	})
+})
@@ -0,0 +1,132 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// JsonPatchAtQ executes JsonPatchAtP, ignoring the patches. Use this function if you only need the PatchFunc
+func JsonPatchAtQ[T any](ctx context.Context, clt client.Client, obj client.Object, path string, value *T) PatchFunc {
+	_, f := JsonPatchAtP(ctx, clt, obj, path, value)
+	return f
+}
+
+// JsonPatchAtP executes JsonPatchAt, panicking for errors. Use this function if you have no use of the returning
+// error. The PatchFunc will still occur at runtime and might return an error; this function is only panicking for
+// the errors that occurred while generating the patch
+func JsonPatchAtP[T any](ctx context.Context, clt client.Client, obj client.Object, path string, value *T) (JsonPatch, PatchFunc) {
+	p, f, e := JsonPatchAt(ctx, clt, obj, path, value)
+	if e != nil {
+		panic(e)
+	}
+	return p, f
+}
+
+// JsonPatchAt uses a JSON-type patch to replace whatever is at the given JSON-Pointer path in obj with value. Unlike
+// JsonPatchSpec, which is fixed to /spec, path may point anywhere in obj, e.g. /status/conditions or
+// /spec/template/spec/containers/0/env. Any key that was built up dynamically (rather than a literal path segment)
+// must be run through SanitizeKeyForJsonPatch before being placed in path. It will return a JsonPatch for you to log,
+// the PatchFunc for you to execute, and an error if it fails to generate the patch
+func JsonPatchAt[T any](ctx context.Context, clt client.Client, obj client.Object, path string, value *T) (JsonPatch, PatchFunc, error) {
+	marshaled, err := json.Marshal(value)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+
+	patch := JsonPatch{fmt.Sprintf("{\"op\": \"replace\", \"path\": \"%s\", \"value\": %s}", path, marshaled)}
+	return patch, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+patch.Get()+"]")), opts...)
+	}, nil
+}
+
+// JsonPatchSliceQ executes JsonPatchSliceP, ignoring the patches. Use this function if you only need the PatchFunc
+func JsonPatchSliceQ[T comparable](ctx context.Context, clt client.Client, obj client.Object, path string, origSlice, newSlice []T) PatchFunc {
+	_, f := JsonPatchSliceP(ctx, clt, obj, path, origSlice, newSlice)
+	return f
+}
+
+// JsonPatchSliceP executes JsonPatchSlice, panicking for errors. Use this function if you have no use of the
+// returning error. The PatchFunc will still occur at runtime and might return an error; this function is only
+// panicking for the errors that occurred while generating the patch
+func JsonPatchSliceP[T comparable](ctx context.Context, clt client.Client, obj client.Object, path string, origSlice, newSlice []T) ([]JsonPatch, PatchFunc) {
+	p, f, e := JsonPatchSlice(ctx, clt, obj, path, origSlice, newSlice)
+	if e != nil {
+		panic(e)
+	}
+	return p, f
+}
+
+// JsonPatchSlice uses JSON-type patches to diff origSlice against newSlice at the given JSON-Pointer path in obj,
+// emitting minimal add/remove/replace ops by index rather than replacing the whole slice. As with
+// JsonPatchFinalizerIn's nil-list handling, an empty origSlice is handled by emitting a single add of the full
+// newSlice. It will return the JsonPatches for you to log, the PatchFunc for you to execute, and an error if it
+// fails to generate the patch
+func JsonPatchSlice[T comparable](ctx context.Context, clt client.Client, obj client.Object, path string, origSlice, newSlice []T) ([]JsonPatch, PatchFunc, error) {
+	if len(origSlice) == 0 {
+		if len(newSlice) == 0 {
+			return nil, nil, &NoPatchRequired{"nothing to patch in slice"}
+		}
+
+		marshaled, err := json.Marshal(newSlice)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		patch := JsonPatch{fmt.Sprintf("{\"op\": \"add\", \"path\": \"%s\", \"value\": %s}", path, marshaled)}
+		return []JsonPatch{patch}, func(opts ...client.PatchOption) error {
+			return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+patch.Get()+"]")), opts...)
+		}, nil
+	}
+
+	var patches []string
+
+	common := len(origSlice)
+	if len(newSlice) < common {
+		common = len(newSlice)
+	}
+
+	for i := 0; i < common; i++ {
+		if origSlice[i] == newSlice[i] {
+			continue
+		}
+		marshaled, err := json.Marshal(newSlice[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		patches = append(patches, fmt.Sprintf("{\"op\": \"replace\", \"path\": \"%s/%d\", \"value\": %s}", path, i, marshaled))
+	}
+
+	if len(newSlice) > len(origSlice) {
+		for i := len(origSlice); i < len(newSlice); i++ {
+			marshaled, err := json.Marshal(newSlice[i])
+			if err != nil {
+				return nil, nil, err
+			}
+			patches = append(patches, fmt.Sprintf("{\"op\": \"add\", \"path\": \"%s/-\", \"value\": %s}", path, marshaled))
+		}
+	} else {
+		// remove extra elements from the tail first, so earlier indexes aren't shifted out from under us
+		for i := len(origSlice) - 1; i >= len(newSlice); i-- {
+			patches = append(patches, fmt.Sprintf("{\"op\": \"remove\", \"path\": \"%s/%d\"}", path, i))
+		}
+	}
+
+	if len(patches) == 0 {
+		return nil, nil, &NoPatchRequired{"nothing to patch in slice"}
+	}
+
+	var patchObjs []JsonPatch
+	for _, p := range patches {
+		patchObjs = append(patchObjs, JsonPatch{p})
+	}
+
+	return patchObjs, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+strings.Join(patches, ",")+"]")), opts...)
+	}, nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Context("JSON patch at an arbitrary path", func() {
+	It("should replace whatever is at the given path", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-at-obj-1",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		labels := map[string]string{"replaced": "true"}
+		Expect(JsonPatchAtQ(ctx, clt, obj, "/metadata/labels", &labels)()).To(Succeed())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Labels["replaced"]).To(Equal("true"))
+	})
+
+	It("should escape quotes and control characters in string values", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-at-obj-2",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		value := `has "quotes" and a newline` + "\n"
+		Expect(JsonPatchAtQ(ctx, clt, obj, "/metadata/annotations", &map[string]string{"tricky": value})()).To(Succeed())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Annotations["tricky"]).To(Equal(value))
+	})
+})
+
+var _ = Context("JSON patch a slice", func() {
+	It("should add the full slice when none existed before", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "dummy-slice-obj-1",
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		Expect(JsonPatchSliceQ(ctx, clt, obj, "/metadata/finalizers", nil, []string{"a/finalizer", "b/finalizer"})()).To(Succeed())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(Equal([]string{"a/finalizer", "b/finalizer"}))
+	})
+
+	It("should replace and remove elements by index", func(ctx SpecContext) {
+		obj := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "dummy-slice-obj-2",
+				Finalizers: []string{"a/finalizer", "b/finalizer", "c/finalizer"},
+			},
+		}
+
+		Expect(clt.Create(ctx, obj)).To(Succeed())
+
+		Expect(JsonPatchSliceQ(ctx, clt, obj, "/metadata/finalizers",
+			[]string{"a/finalizer", "b/finalizer", "c/finalizer"},
+			[]string{"a/finalizer", "replaced/finalizer"},
+		)()).To(Succeed())
+
+		Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+		Expect(obj.Finalizers).To(Equal([]string{"a/finalizer", "replaced/finalizer"}))
+	})
+
+	It("should return an error when there is nothing to patch", func(ctx SpecContext) {
+		_, _, err := JsonPatchSlice(ctx, clt, &corev1.Namespace{}, "/metadata/finalizers", []string{"a"}, []string{"a"})
+		Expect(err).To(MatchError("nothing to patch in slice"))
+	})
+})
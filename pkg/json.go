@@ -16,8 +16,9 @@ type (
 	JsonPatch struct {
 		patch string
 	}
-	// PatchFunc is the function for you to execute to perform the patch
-	PatchFunc func() error
+	// PatchFunc is the function for you to execute to perform the patch. It accepts any client.PatchOption,
+	// allowing e.g. PatchFunc.WithServerDryRun to request a server-side dry run via client.DryRunAll
+	PatchFunc func(opts ...client.PatchOption) error
 	// NoPatchRequired is the error used to indicate your tool instructions have resulted in no patches being created
 	NoPatchRequired struct {
 		message string
@@ -64,21 +65,25 @@ func JsonPatchFinalizerInP(ctx context.Context, clt client.Client, obj client.Ob
 }
 
 // JsonPatchFinalizerIn uses JSON-type patches to add a finalizer to obj. It will return the JsonPatch for you to log,
-// the PatchFunc for you to execute, and an error if it fails to generate the patch. This function isn't currently
-// returning an error, but this might change in the future
+// the PatchFunc for you to execute, and an error if it fails to generate the patch
 func JsonPatchFinalizerIn(ctx context.Context, clt client.Client, obj client.Object, finalizer string) (JsonPatch, PatchFunc, error) {
 	// create JSON patch adding finalizer
+	marshaledFinalizer, err := json.Marshal(finalizer)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+
 	var addFinalizerPatch JsonPatch
 	if obj.GetFinalizers() == nil {
 		// no finalizer found, add a list of the one finalizer
-		addFinalizerPatch = JsonPatch{fmt.Sprintf("{\"op\": \"add\", \"path\": \"/metadata/finalizers\", \"value\": [\"%s\"]}", finalizer)}
+		addFinalizerPatch = JsonPatch{fmt.Sprintf("{\"op\": \"add\", \"path\": \"/metadata/finalizers\", \"value\": [%s]}", marshaledFinalizer)}
 	} else {
 		// other finalizers exists, add the finalizer to the existing list
-		addFinalizerPatch = JsonPatch{fmt.Sprintf("{\"op\": \"add\", \"path\": \"/metadata/finalizers/-\", \"value\": \"%s\"}", finalizer)}
+		addFinalizerPatch = JsonPatch{fmt.Sprintf("{\"op\": \"add\", \"path\": \"/metadata/finalizers/-\", \"value\": %s}", marshaledFinalizer)}
 	}
 
-	return addFinalizerPatch, func() error {
-		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+addFinalizerPatch.Get()+"]")))
+	return addFinalizerPatch, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+addFinalizerPatch.Get()+"]")), opts...)
 	}, nil
 }
 
@@ -121,8 +126,8 @@ func JsonPatchFinalizerOut(ctx context.Context, clt client.Client, obj client.Ob
 		}
 	}
 
-	return removeFinalizerPatch, func() error {
-		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+removeFinalizerPatch.Get()+"]")))
+	return removeFinalizerPatch, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+removeFinalizerPatch.Get()+"]")), opts...)
 	}, nil
 }
 
@@ -143,12 +148,12 @@ func JsonPatchMapP(ctx context.Context, clt client.Client, obj client.Object, pa
 	return p, f
 }
 
-// JsonPatchMap uses JSON-type patches to add or replace all members in the given path for the given obj with the member
-// in newMap; we use origMap to determine whether we need to add or replace. It will return JsonPatches for you to log,
-// the PatchFunc for you to execute, and an error if it fails to generate the patch
-func JsonPatchMap(ctx context.Context, clt client.Client, obj client.Object, path string, origMap, newMap map[string]string) ([]JsonPatch, PatchFunc, error) {
-	patchNewMapTemplate := "{\"op\": \"add\", \"path\": \"%s\", \"value\": {\"%s\": \"%s\"}}"
-	patchExistingMapTemplate := "{\"op\": \"%s\", \"path\": \"%s/%s\", \"value\": \"%s\"}"
+// jsonPatchMapOps computes the raw JSON-Patch operation strings needed to bring the member in the given path from
+// origMap to newMap; we use origMap to determine whether we need to add or replace. Shared by JsonPatchMap and
+// PatchBuilder.UpsertMap
+func jsonPatchMapOps(path string, origMap, newMap map[string]string) []string {
+	patchNewMapTemplate := "{\"op\": \"add\", \"path\": \"%s\", \"value\": {\"%s\": %s}}"
+	patchExistingMapTemplate := "{\"op\": \"%s\", \"path\": \"%s/%s\", \"value\": %s}"
 
 	var patches []string
 
@@ -156,29 +161,40 @@ func JsonPatchMap(ctx context.Context, clt client.Client, obj client.Object, pat
 		// no previous member exists - load add all given member to the patch
 		first := true
 		for k, v := range newMap {
+			marshaledVal, _ := json.Marshal(v)
 			if first {
 				// if the original map doesn't exist, the key should be un-sanitized and part of the value
-				patches = append(patches, fmt.Sprintf(patchNewMapTemplate, path, UnSanitizeKeyForJsonPatch(k), v))
+				patches = append(patches, fmt.Sprintf(patchNewMapTemplate, path, UnSanitizeKeyForJsonPatch(k), marshaledVal))
 				first = false
 			} else {
-				patches = append(patches, fmt.Sprintf(patchExistingMapTemplate, "add", path, k, v))
+				patches = append(patches, fmt.Sprintf(patchExistingMapTemplate, "add", path, k, marshaledVal))
 			}
 		}
 	} else {
 		// found previous member - verify add/replace/exists before loading the given member to the patch
 		for k, v := range newMap {
+			marshaledVal, _ := json.Marshal(v)
 			if value, found := origMap[k]; found {
 				if v != value {
 					// found existing member with the key and a different value - replace
-					patches = append(patches, fmt.Sprintf(patchExistingMapTemplate, "replace", path, k, v))
+					patches = append(patches, fmt.Sprintf(patchExistingMapTemplate, "replace", path, k, marshaledVal))
 				}
 			} else {
 				// existing member with the key not found - add
-				patches = append(patches, fmt.Sprintf(patchExistingMapTemplate, "add", path, k, v))
+				patches = append(patches, fmt.Sprintf(patchExistingMapTemplate, "add", path, k, marshaledVal))
 			}
 		}
 	}
 
+	return patches
+}
+
+// JsonPatchMap uses JSON-type patches to add or replace all members in the given path for the given obj with the member
+// in newMap; we use origMap to determine whether we need to add or replace. It will return JsonPatches for you to log,
+// the PatchFunc for you to execute, and an error if it fails to generate the patch
+func JsonPatchMap(ctx context.Context, clt client.Client, obj client.Object, path string, origMap, newMap map[string]string) ([]JsonPatch, PatchFunc, error) {
+	patches := jsonPatchMapOps(path, origMap, newMap)
+
 	var patchObjs []JsonPatch
 	for _, p := range patches {
 		patchObjs = append(patchObjs, JsonPatch{p})
@@ -187,8 +203,8 @@ func JsonPatchMap(ctx context.Context, clt client.Client, obj client.Object, pat
 	if len(patches) < 1 {
 		return patchObjs, nil, &NoPatchRequired{"nothing to patch in map"}
 	}
-	return patchObjs, func() error {
-		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+strings.Join(patches, ",")+"]")))
+	return patchObjs, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+strings.Join(patches, ",")+"]")), opts...)
 	}, nil
 }
 
@@ -212,13 +228,5 @@ func JsonPatchSpecP[T interface{}](ctx context.Context, clt client.Client, obj c
 // JsonPatchSpec uses JSON-type patches to replace a Spec in obj. It will return a JsonPatch for you to log, the
 // PatchFunc for you to execute, and an error if it fails to generate the patch
 func JsonPatchSpec[T interface{}](ctx context.Context, clt client.Client, obj client.Object, spec *T) (JsonPatch, PatchFunc, error) {
-	marshaled, err := json.Marshal(spec)
-	if err != nil {
-		return JsonPatch{""}, nil, err
-	}
-
-	patch := JsonPatch{"{\"op\": \"replace\", \"path\": \"/spec\", \"value\": " + string(marshaled) + "}"}
-	return patch, func() error {
-		return clt.Patch(ctx, obj, client.RawPatch(types.JSONPatchType, []byte("["+patch.Get()+"]")))
-	}, nil
+	return JsonPatchAt(ctx, clt, obj, "/spec", spec)
 }
@@ -0,0 +1,278 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// splitJsonPointer breaks a JSON-Pointer path such as "/metadata/annotations" into its sequence of reference tokens,
+// e.g. ["metadata", "annotations"]
+func splitJsonPointer(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// nestAtPath wraps value in the nested JSON object described by the given JSON-Pointer path, e.g. turning
+// ("/metadata/annotations", {"k":"v"}) into {"metadata": {"annotations": {"k":"v"}}}. It is used to turn a patch for
+// a sub-path into a document that can be sent as a strategic-merge or merge patch, both of which operate on the
+// whole object rather than a single JSON-Pointer location
+func nestAtPath(path string, value json.RawMessage) ([]byte, error) {
+	keys := splitJsonPointer(path)
+	nested := value
+	for i := len(keys) - 1; i >= 0; i-- {
+		wrapped, err := json.Marshal(map[string]json.RawMessage{UnSanitizeKeyForJsonPatch(keys[i]): nested})
+		if err != nil {
+			return nil, err
+		}
+		nested = wrapped
+	}
+	return nested, nil
+}
+
+// isUnstructured reports whether obj has no registered Go type to deserialize into, meaning it must be treated as a
+// CRD without a compiled-in API type
+func isUnstructured(obj client.Object) bool {
+	_, ok := obj.(*unstructured.Unstructured)
+	return ok
+}
+
+// isBuiltIn reports whether obj's Go type is one of the compiled-in Kubernetes API types (as opposed to a CRD's own
+// API type). This is what actually determines whether the API server will accept a strategic-merge patch for obj:
+// only built-in types ship the patchStrategy/patchMergeKey struct tags the API server's OpenAPI schema needs to
+// apply one, so CRDs - even typed, scheme-registered ones that aren't unstructured.Unstructured - must fall back to
+// a three-way JSON merge patch
+func isBuiltIn(obj client.Object) bool {
+	return strings.HasPrefix(reflect.TypeOf(obj).Elem().PkgPath(), "k8s.io/api/")
+}
+
+// StrategicPatchSpecQ executes StrategicPatchSpecP, ignoring the patches. Use this function if you only need the
+// PatchFunc
+func StrategicPatchSpecQ[T interface{}](ctx context.Context, clt client.Client, obj client.Object, origSpec, newSpec *T) PatchFunc {
+	_, f := StrategicPatchSpecP(ctx, clt, obj, origSpec, newSpec)
+	return f
+}
+
+// StrategicPatchSpecP executes StrategicPatchSpec, panicking for errors. Use this function if you have no use of the
+// returning error. The PatchFunc will still occur at runtime and might return an error; this function is only
+// panicking for the errors that occurred while generating the patch
+func StrategicPatchSpecP[T interface{}](ctx context.Context, clt client.Client, obj client.Object, origSpec, newSpec *T) (JsonPatch, PatchFunc) {
+	p, f, e := StrategicPatchSpec(ctx, clt, obj, origSpec, newSpec)
+	if e != nil {
+		panic(e)
+	}
+	return p, f
+}
+
+// StrategicPatchSpec replaces a Spec in obj, picking the patch strategy based on whether obj is a compiled-in
+// Kubernetes type or a CRD. For built-ins it computes a strategic-merge patch from the difference between origSpec
+// and newSpec via strategicpatch.CreateTwoWayMergePatch, so it is safe to apply by value even when another
+// controller has concurrently changed unrelated fields. CRDs don't support strategic-merge patch (the API server
+// rejects it), so those fall back to a three-way JSON merge patch via jsonmergepatch.CreateThreeWayJSONMergePatch. It
+// returns a JsonPatch for you to log, the PatchFunc for you to execute, and an error if it fails to generate the
+// patch
+func StrategicPatchSpec[T interface{}](ctx context.Context, clt client.Client, obj client.Object, origSpec, newSpec *T) (JsonPatch, PatchFunc, error) {
+	origJson, err := json.Marshal(origSpec)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+	newJson, err := json.Marshal(newSpec)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+
+	var specPatch []byte
+	patchType := types.StrategicMergePatchType
+	if isBuiltIn(obj) {
+		specPatch, err = strategicpatch.CreateTwoWayMergePatch(origJson, newJson, newSpec)
+	} else {
+		patchType = types.MergePatchType
+		specPatch, err = jsonmergepatch.CreateThreeWayJSONMergePatch(origJson, newJson, origJson)
+	}
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+
+	patchBytes, err := nestAtPath("/spec", specPatch)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+
+	patch := JsonPatch{string(patchBytes)}
+	return patch, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(patchType, []byte(patch.Get())), opts...)
+	}, nil
+}
+
+// StrategicPatchMapQ executes StrategicPatchMapP, ignoring the patches. Use this function if you only need the
+// PatchFunc
+func StrategicPatchMapQ(ctx context.Context, clt client.Client, obj client.Object, path string, origMap, newMap map[string]string) PatchFunc {
+	_, f := StrategicPatchMapP(ctx, clt, obj, path, origMap, newMap)
+	return f
+}
+
+// StrategicPatchMapP executes StrategicPatchMap, panicking for errors. Use this function if you have no use of the
+// returning error. The PatchFunc will still occur at runtime and might return an error; this function is only
+// panicking for the errors that occurred while generating the patch
+func StrategicPatchMapP(ctx context.Context, clt client.Client, obj client.Object, path string, origMap, newMap map[string]string) (JsonPatch, PatchFunc) {
+	p, f, e := StrategicPatchMap(ctx, clt, obj, path, origMap, newMap)
+	if e != nil {
+		panic(e)
+	}
+	return p, f
+}
+
+// StrategicPatchMap uses a JSON merge patch to bring the member in the given path for obj from origMap to newMap -
+// built directly for a typed obj (a plain string map has no patchStrategy/patchMergeKey directives to merit
+// strategic-merge), or via a three-way JSON merge patch for unstructured/CRD objects lacking a compiled-in API type.
+// It returns the JsonPatch for you to log, the PatchFunc for you to execute, and an error if it fails to generate the
+// patch
+func StrategicPatchMap(ctx context.Context, clt client.Client, obj client.Object, path string, origMap, newMap map[string]string) (JsonPatch, PatchFunc, error) {
+	origJson, err := json.Marshal(origMap)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+	newJson, err := json.Marshal(newMap)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+
+	var mapPatch []byte
+	if isUnstructured(obj) {
+		mapPatch, err = jsonmergepatch.CreateThreeWayJSONMergePatch(origJson, newJson, origJson)
+		if err != nil {
+			return JsonPatch{""}, nil, err
+		}
+	} else {
+		// a plain string map carries no patchStrategy/patchMergeKey directives for CreateTwoWayMergePatch to key
+		// off (it also only accepts a struct, not a map, as its dataStruct argument) - build the RFC 7386 merge
+		// document directly instead: changed/added keys get their new value, removed keys get null
+		mergeMap := map[string]*string{}
+		for k, v := range newMap {
+			if orig, found := origMap[k]; !found || orig != v {
+				val := v
+				mergeMap[k] = &val
+			}
+		}
+		for k := range origMap {
+			if _, found := newMap[k]; !found {
+				mergeMap[k] = nil
+			}
+		}
+		mapPatch, err = json.Marshal(mergeMap)
+		if err != nil {
+			return JsonPatch{""}, nil, err
+		}
+	}
+
+	patchBytes, err := nestAtPath(path, mapPatch)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+	if len(mapPatch) <= 2 {
+		// an empty mergeMap, or a CreateThreeWayJSONMergePatch result, marshals to "{}" when there is nothing to patch
+		return JsonPatch{""}, nil, &NoPatchRequired{"nothing to patch in map"}
+	}
+
+	patch := JsonPatch{string(patchBytes)}
+	return patch, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(types.MergePatchType, []byte(patch.Get())), opts...)
+	}, nil
+}
+
+// MergePatchFinalizerInQ executes MergePatchFinalizerInP, ignoring the patches. Use this function if you only need
+// the PatchFunc
+func MergePatchFinalizerInQ(ctx context.Context, clt client.Client, obj client.Object, finalizer string) PatchFunc {
+	_, f := MergePatchFinalizerInP(ctx, clt, obj, finalizer)
+	return f
+}
+
+// MergePatchFinalizerInP executes MergePatchFinalizerIn, panicking for errors. Use this function if you have no use
+// of the returning error. The PatchFunc will still occur at runtime and might return an error; this function is only
+// panicking for the errors that occurred while generating the patch
+func MergePatchFinalizerInP(ctx context.Context, clt client.Client, obj client.Object, finalizer string) (JsonPatch, PatchFunc) {
+	p, f, e := MergePatchFinalizerIn(ctx, clt, obj, finalizer)
+	if e != nil {
+		panic(e)
+	}
+	return p, f
+}
+
+// MergePatchFinalizerIn uses a JSON merge patch (RFC 7386) to add a finalizer to obj. Unlike JsonPatchFinalizerIn,
+// the finalizer is added by value rather than by list index. The patch carries obj's whole finalizer list with
+// finalizer appended, and a merge patch replaces that list on the server as a whole, so a finalizer another client
+// added after obj was read is dropped. It returns the JsonPatch for you to log, the PatchFunc for you to execute, and
+// an error if it fails to generate the patch
+func MergePatchFinalizerIn(ctx context.Context, clt client.Client, obj client.Object, finalizer string) (JsonPatch, PatchFunc, error) {
+	finalizers := obj.GetFinalizers()
+	for _, fin := range finalizers {
+		if fin == finalizer {
+			return JsonPatch{""}, nil, &NoPatchRequired{"finalizer already present"}
+		}
+	}
+
+	marshaled, err := json.Marshal(append(finalizers, finalizer))
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+
+	patch := JsonPatch{fmt.Sprintf("{\"metadata\": {\"finalizers\": %s}}", marshaled)}
+	return patch, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(types.MergePatchType, []byte(patch.Get())), opts...)
+	}, nil
+}
+
+// MergePatchFinalizerOutQ executes MergePatchFinalizerOutP, ignoring the patches. Use this function if you only need
+// the PatchFunc
+func MergePatchFinalizerOutQ(ctx context.Context, clt client.Client, obj client.Object, finalizer string) PatchFunc {
+	_, f := MergePatchFinalizerOutP(ctx, clt, obj, finalizer)
+	return f
+}
+
+// MergePatchFinalizerOutP executes MergePatchFinalizerOut, panicking for errors. Use this function if you have no
+// use of the returning error. The PatchFunc will still occur at runtime and might return an error; this function is
+// only panicking for the errors that occurred while generating the patch
+func MergePatchFinalizerOutP(ctx context.Context, clt client.Client, obj client.Object, finalizer string) (JsonPatch, PatchFunc) {
+	p, f, e := MergePatchFinalizerOut(ctx, clt, obj, finalizer)
+	if e != nil {
+		panic(e)
+	}
+	return p, f
+}
+
+// MergePatchFinalizerOut uses a JSON merge patch (RFC 7386) to remove a finalizer from obj by value, rather than the
+// list index JsonPatchFinalizerOut relies on. It returns the JsonPatch for you to log, the PatchFunc for you to
+// execute, and an error if it fails to generate the patch
+func MergePatchFinalizerOut(ctx context.Context, clt client.Client, obj client.Object, finalizer string) (JsonPatch, PatchFunc, error) {
+	finalizers := obj.GetFinalizers()
+	remaining := make([]string, 0, len(finalizers))
+	found := false
+	for _, fin := range finalizers {
+		if fin == finalizer {
+			found = true
+			continue
+		}
+		remaining = append(remaining, fin)
+	}
+	if !found {
+		return JsonPatch{""}, nil, &NoPatchRequired{"finalizer not found"}
+	}
+
+	marshaled, err := json.Marshal(remaining)
+	if err != nil {
+		return JsonPatch{""}, nil, err
+	}
+
+	patch := JsonPatch{fmt.Sprintf("{\"metadata\": {\"finalizers\": %s}}", marshaled)}
+	return patch, func(opts ...client.PatchOption) error {
+		return clt.Patch(ctx, obj, client.RawPatch(types.MergePatchType, []byte(patch.Get())), opts...)
+	}, nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2024 Red Hat, Inc.
+
+package pkg
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	testdata "github.com/rhecosystemappeng/patch-utils/pkg/testdata/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Context("Strategic merge patch", func() {
+	Context("a spec", func() {
+		It("should work with any spec", func(ctx SpecContext) {
+			obj := &testdata.DummyCRD{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "dummy-strategic-spec-obj-1",
+				},
+				Spec: testdata.DummyCRDSpec{
+					FirstDummyValue:  "replace-me",
+					SecondDummyValue: "leave-me-alone",
+				},
+			}
+
+			Expect(clt.Create(ctx, obj)).To(Succeed())
+
+			origSpec := obj.Spec
+			newSpec := obj.Spec
+			newSpec.FirstDummyValue = "replaced"
+
+			Expect(StrategicPatchSpecQ(ctx, clt, obj, &origSpec, &newSpec)()).To(Succeed())
+
+			Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+			Expect(obj.Spec.FirstDummyValue).To(Equal("replaced"))
+			Expect(obj.Spec.SecondDummyValue).To(Equal("leave-me-alone"))
+		})
+	})
+
+	Context("a map", func() {
+		It("should work when patching members into an empty map", func(ctx SpecContext) {
+			obj := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "dummy-strategic-map-obj-1",
+				},
+			}
+
+			Expect(clt.Create(ctx, obj)).To(Succeed())
+
+			Expect(StrategicPatchMapQ(ctx, clt, obj, "/metadata/annotations", obj.Annotations, map[string]string{
+				"annotation_key1": "annotation_value1",
+			})()).To(Succeed())
+
+			Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+			Expect(obj.Annotations["annotation_key1"]).To(Equal("annotation_value1"))
+		})
+	})
+
+	Context("a finalizer in", func() {
+		It("should work when no other finalizers exist", func(ctx SpecContext) {
+			obj := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "dummy-strategic-finin-obj-1",
+				},
+			}
+
+			Expect(clt.Create(ctx, obj)).To(Succeed())
+
+			Expect(MergePatchFinalizerInQ(ctx, clt, obj, "add-my/finalizer")()).To(Succeed())
+
+			Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+			Expect(obj.Finalizers).To(ContainElement("add-my/finalizer"))
+		})
+
+		It("should no-op when the finalizer is already present", func(ctx SpecContext) {
+			obj := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "dummy-strategic-finin-obj-2",
+					Finalizers: []string{"already-existing/finalizer"},
+				},
+			}
+
+			Expect(clt.Create(ctx, obj)).To(Succeed())
+
+			_, _, err := MergePatchFinalizerIn(ctx, clt, obj, "already-existing/finalizer")
+			Expect(err).To(MatchError("finalizer already present"))
+		})
+	})
+
+	Context("a finalizer out", func() {
+		It("should work when multiple finalizers exist", func(ctx SpecContext) {
+			obj := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "dummy-strategic-finout-obj-1",
+					Finalizers: []string{"remove-this/finalizer", "do-not-remove-this/finalizer"},
+				},
+			}
+
+			Expect(clt.Create(ctx, obj)).To(Succeed())
+
+			Expect(MergePatchFinalizerOutQ(ctx, clt, obj, "remove-this/finalizer")()).To(Succeed())
+
+			Expect(clt.Get(ctx, types.NamespacedName{Name: obj.Name}, obj)).To(Succeed())
+			Expect(obj.Finalizers).To(ContainElement("do-not-remove-this/finalizer"))
+			Expect(obj.Finalizers).ToNot(ContainElement("remove-this/finalizer"))
+		})
+
+		It("should return an error when attempting to remove a non-existing finalizer", func(ctx SpecContext) {
+			obj := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "dummy-strategic-finout-obj-2",
+					Finalizers: []string{"do-not-remove-this/finalizer"},
+				},
+			}
+
+			Expect(clt.Create(ctx, obj)).To(Succeed())
+
+			_, _, err := MergePatchFinalizerOut(ctx, clt, obj, "remove-this/finalizer")
+			Expect(err).To(MatchError("finalizer not found"))
+		})
+	})
+})